@@ -17,7 +17,11 @@
 
 package cpc
 
-import "fmt"
+import (
+	"fmt"
+
+	"github.com/apache/datasketches-go/internal"
+)
 
 type CpcCompressedState struct {
 	CsvIsValid    bool
@@ -75,7 +79,9 @@ func (c *CpcCompressedState) getFormat() CpcFormat {
 }
 
 func (c *CpcCompressedState) uncompress(seed uint64) (*CpcSketch, error) {
-	//ThetaUtil.checkSeedHashes(ThetaUtil.computeSeedHash(seed), c.SeedHash)
+	if gotSeedHash := internal.ComputeSeedHash(seed); gotSeedHash != c.SeedHash {
+		return nil, fmt.Errorf("seed hash mismatch: sketch was serialized with a different seed, got %d, expected %d", gotSeedHash, c.SeedHash)
+	}
 	sketch, err := NewCpcSketch(c.LgK, seed)
 	if err != nil {
 		return nil, err
@@ -88,28 +94,12 @@ func (c *CpcCompressedState) uncompress(seed uint64) (*CpcSketch, error) {
 	sketch.hipEstAccum = c.HipEstAccum
 	sketch.slidingWindow = nil
 	sketch.pairTable = nil
-	//uncompress(c, sketch)
-	return sketch, err
+	if err := uncompress(c, sketch); err != nil {
+		return nil, err
+	}
+	return sketch, nil
 }
 
-/*
-  //also used in test
-  static CpcSketch uncompress(final CompressedState source, final long seed) {
-    ThetaUtil.checkSeedHashes(ThetaUtil.computeSeedHash(seed), source.seedHash);
-    final CpcSketch sketch = new CpcSketch(source.lgK, seed);
-    sketch.numCoupons = source.numCoupons;
-    sketch.windowOffset = source.getWindowOffset();
-    sketch.fiCol = source.fiCol;
-    sketch.mergeFlag = source.mergeFlag;
-    sketch.kxp = source.kxp;
-    sketch.hipEstAccum = source.hipEstAccum;
-    sketch.slidingWindow = null;
-    sketch.pairTable = null;
-    CpcCompression.uncompress(source, sketch);
-    return sketch;
-  }
-*/
-
 func importFromMemory(bytes []byte) (*CpcCompressedState, error) {
 	if err := checkLoPreamble(bytes); err != nil {
 		return nil, err
@@ -149,115 +139,119 @@ func importFromMemory(bytes []byte) (*CpcCompressedState, error) {
 			return nil, err
 		}
 		state.CsvStream = getSvStream(bytes)
+	case CpcFormatPinnedSlidingMergedNosv:
+		state.FiCol = getFiCol(bytes)
+		state.NumCoupons = getNumCoupons(bytes)
+		state.CwLengthInts = getWLengthInts(bytes)
+		if err := checkCapacity(len(bytes), state.getRequiredSerializedBytes()); err != nil {
+			return nil, err
+		}
+		state.CwStream = getWStream(bytes)
+	case CpcFormatPinnedSlidingHipNosv:
+		state.FiCol = getFiCol(bytes)
+		state.NumCoupons = getNumCoupons(bytes)
+		state.CwLengthInts = getWLengthInts(bytes)
+		state.Kxp = getKxP(bytes)
+		state.HipEstAccum = getHipAccum(bytes)
+		if err := checkCapacity(len(bytes), state.getRequiredSerializedBytes()); err != nil {
+			return nil, err
+		}
+		state.CwStream = getWStream(bytes)
+	case CpcFormatPinnedSlidingMerged:
+		state.FiCol = getFiCol(bytes)
+		state.NumCoupons = getNumCoupons(bytes)
+		state.NumCsv = getNumSv(bytes)
+		state.CsvLengthInts = getSvLengthInts(bytes)
+		state.CwLengthInts = getWLengthInts(bytes)
+		if err := checkCapacity(len(bytes), state.getRequiredSerializedBytes()); err != nil {
+			return nil, err
+		}
+		state.CwStream = getWStream(bytes)
+		state.CsvStream = getSvStream(bytes)
+	case CpcFormatPinnedSlidingHip:
+		state.FiCol = getFiCol(bytes)
+		state.NumCoupons = getNumCoupons(bytes)
+		state.NumCsv = getNumSv(bytes)
+		state.CsvLengthInts = getSvLengthInts(bytes)
+		state.CwLengthInts = getWLengthInts(bytes)
+		state.Kxp = getKxP(bytes)
+		state.HipEstAccum = getHipAccum(bytes)
+		if err := checkCapacity(len(bytes), state.getRequiredSerializedBytes()); err != nil {
+			return nil, err
+		}
+		state.CwStream = getWStream(bytes)
+		state.CsvStream = getSvStream(bytes)
 	default:
-		panic("not implemented")
+		return nil, fmt.Errorf("unrecognized format ordinal: %d", fmtOrd)
 	}
 	return state, nil
 }
 
-/*
-static CompressedState importFromMemory(final Memory mem) {
-    checkLoPreamble(mem);
-    rtAssert(isCompressed(mem));
-    final int lgK = getLgK(mem);
-    final short seedHash = getSeedHash(mem);
-    final CompressedState state = new CompressedState(lgK, seedHash);
-    final int fmtOrd = getFormatOrdinal(mem);
-    final Format format = Format.ordinalToFormat(fmtOrd);
-    state.mergeFlag = !((fmtOrd & 1) > 0); //merge flag is complement of HIP
-    state.csvIsValid = (fmtOrd & 2) > 0;
-    state.windowIsValid = (fmtOrd & 4) > 0;
+// exportToMemory serializes sketch to its compressed byte-array form,
+// choosing whichever of the eight formats fits the sketch's current
+// flavor, and is the inverse of importFromMemory.
+func exportToMemory(sketch *CpcSketch) ([]byte, error) {
+	state, err := compress(sketch)
+	if err != nil {
+		return nil, err
+	}
+	format := state.getFormat()
+	preInts := getDefinedPreInts(format)
+	bytes := make([]byte, 4*(preInts+state.CsvLengthInts+state.CwLengthInts))
 
-    switch (format) {
-      case EMPTY_MERGED :
-      case EMPTY_HIP : {
-        checkCapacity(mem.getCapacity(), 8L);
-        break;
-      }
-      case SPARSE_HYBRID_MERGED : {
-        //state.fiCol = getFiCol(mem);
-        state.numCoupons = getNumCoupons(mem);
-        state.numCsv = (int) state.numCoupons; //only true for sparse_hybrid
-        state.csvLengthInts = getSvLengthInts(mem);
-        //state.cwLength = getCwLength(mem);
-        //state.kxp = getKxP(mem);
-        //state.hipEstAccum = getHipAccum(mem);
-        checkCapacity(mem.getCapacity(), state.getRequiredSerializedBytes());
-        //state.cwStream = getCwStream(mem);
-        state.csvStream = getSvStream(mem);
-        break;
-      }
-      case SPARSE_HYBRID_HIP : {
-        //state.fiCol = getFiCol(mem);
-        state.numCoupons = getNumCoupons(mem);
-        state.numCsv = (int) state.numCoupons; //only true for sparse_hybrid
-        state.csvLengthInts = getSvLengthInts(mem);
-        //state.cwLength = getCwLength(mem);
-        state.kxp = getKxP(mem);
-        state.hipEstAccum = getHipAccum(mem);
-        checkCapacity(mem.getCapacity(), state.getRequiredSerializedBytes());
-        //state.cwStream = getCwStream(mem);
-        state.csvStream = getSvStream(mem);
-        break;
-      }
-      case PINNED_SLIDING_MERGED_NOSV : {
-        state.fiCol = getFiCol(mem);
-        state.numCoupons = getNumCoupons(mem);
-        //state.numCsv = getNumCsv(mem);
-        //state.csvLength = getCsvLength(mem);
-        state.cwLengthInts = getWLengthInts(mem);
-        //state.kxp = getKxP(mem);
-        //state.hipEstAccum = getHipAccum(mem);
-        checkCapacity(mem.getCapacity(), state.getRequiredSerializedBytes());
-        state.cwStream = getWStream(mem);
-        //state.csvStream = getCsvStream(mem);
-        break;
-      }
-      case PINNED_SLIDING_HIP_NOSV : {
-        state.fiCol = getFiCol(mem);
-        state.numCoupons = getNumCoupons(mem);
-        //state.numCsv = getNumCsv(mem);
-        //state.csvLength = getCsvLength(mem);
-        state.cwLengthInts = getWLengthInts(mem);
-        state.kxp = getKxP(mem);
-        state.hipEstAccum = getHipAccum(mem);
-        checkCapacity(mem.getCapacity(), state.getRequiredSerializedBytes());
-        state.cwStream = getWStream(mem);
-        //state.csvStream = getCsvStream(mem);
-        break;
-      }
-      case PINNED_SLIDING_MERGED : {
-        state.fiCol = getFiCol(mem);
-        state.numCoupons = getNumCoupons(mem);
-        state.numCsv = getNumSv(mem);
-        state.csvLengthInts = getSvLengthInts(mem);
-        state.cwLengthInts = getWLengthInts(mem);
-        //state.kxp = getKxP(mem);
-        //state.hipEstAccum = getHipAccum(mem);
-        checkCapacity(mem.getCapacity(), state.getRequiredSerializedBytes());
-        state.cwStream = getWStream(mem);
-        state.csvStream = getSvStream(mem);
-        break;
-      }
-      case PINNED_SLIDING_HIP : {
-        state.fiCol = getFiCol(mem);
-        state.numCoupons = getNumCoupons(mem);
-        state.numCsv = getNumSv(mem);
-        state.csvLengthInts = getSvLengthInts(mem);
-        state.cwLengthInts = getWLengthInts(mem);
-        state.kxp = getKxP(mem);
-        state.hipEstAccum = getHipAccum(mem);
-        checkCapacity(mem.getCapacity(), state.getRequiredSerializedBytes());
-        state.cwStream = getWStream(mem);
-        state.csvStream = getSvStream(mem);
-        break;
-      }
-    }
-    checkCapacity(mem.getCapacity(),
-        4L * (getPreInts(mem) + state.csvLengthInts + state.cwLengthInts));
-    return state;
-  }
-*/
+	putPreInts(bytes, preInts)
+	putSerVer(bytes)
+	putFamilyId(bytes)
+	putLgK(bytes, state.LgK)
+	putFormatOrdinal(bytes, int(format))
+	putSeedHash(bytes, state.SeedHash)
+
+	switch format {
+	case CpcformatEmptyMerged, CpcFormatEmptyHip:
+		// no further fields: the 8-byte preamble carries the whole sketch.
+	case CpcFormatSparseHybridMerged:
+		putNumCoupons(bytes, state.NumCoupons)
+		putSvLengthInts(bytes, 3, state.CsvLengthInts)
+		putSvStream(bytes, 4, state.CsvStream)
+	case CpcFormatSparceHybridHip:
+		putNumCoupons(bytes, state.NumCoupons)
+		putSvLengthInts(bytes, 3, state.CsvLengthInts)
+		putKxP(bytes, 4, state.Kxp)
+		putHipAccum(bytes, 6, state.HipEstAccum)
+		putSvStream(bytes, 8, state.CsvStream)
+	case CpcFormatPinnedSlidingMergedNosv:
+		putFiCol(bytes, state.FiCol)
+		putNumCoupons(bytes, state.NumCoupons)
+		putWLengthInts(bytes, 3, state.CwLengthInts)
+		putWStream(bytes, 4, state.CwStream)
+	case CpcFormatPinnedSlidingHipNosv:
+		putFiCol(bytes, state.FiCol)
+		putNumCoupons(bytes, state.NumCoupons)
+		putWLengthInts(bytes, 3, state.CwLengthInts)
+		putKxP(bytes, 4, state.Kxp)
+		putHipAccum(bytes, 6, state.HipEstAccum)
+		putWStream(bytes, 8, state.CwStream)
+	case CpcFormatPinnedSlidingMerged:
+		putFiCol(bytes, state.FiCol)
+		putNumCoupons(bytes, state.NumCoupons)
+		putNumSv(bytes, 3, state.NumCsv)
+		putSvLengthInts(bytes, 4, state.CsvLengthInts)
+		putWLengthInts(bytes, 5, state.CwLengthInts)
+		putWStream(bytes, 6, state.CwStream)
+		putSvStream(bytes, 6+state.CwLengthInts, state.CsvStream)
+	case CpcFormatPinnedSlidingHip:
+		putFiCol(bytes, state.FiCol)
+		putNumCoupons(bytes, state.NumCoupons)
+		putNumSv(bytes, 3, state.NumCsv)
+		putSvLengthInts(bytes, 4, state.CsvLengthInts)
+		putWLengthInts(bytes, 5, state.CwLengthInts)
+		putKxP(bytes, 6, state.Kxp)
+		putHipAccum(bytes, 8, state.HipEstAccum)
+		putWStream(bytes, 10, state.CwStream)
+		putSvStream(bytes, 10+state.CwLengthInts, state.CsvStream)
+	}
+	return bytes, nil
+}
 
 func getDefinedPreInts(format CpcFormat) int {
 	return int(preIntsDefs[format])
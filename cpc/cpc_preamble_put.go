@@ -0,0 +1,134 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cpc
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// Byte layout written by these helpers (little-endian, word = 4 bytes),
+// mirroring what importFromMemory's get* accessors expect and matching
+// the per-format sizes in preIntsDefs:
+//
+//	word 0: [preInts byte][serVer byte][familyID byte][lgK byte]
+//	word 1: [fiCol byte][formatOrdinal byte][seedHash (2 bytes)]
+//	word 2: numCoupons             (every format with preInts >= 4)
+//	word 3: svLengthInts (SPARSE_HYBRID), cwLengthInts (*_NOSV), or
+//	        numSv (PINNED_SLIDING_MERGED/HIP, which also carry a CSV)
+//	word 4: svLengthInts (PINNED_SLIDING_MERGED/HIP only)
+//	word 5: cwLengthInts (PINNED_SLIDING_MERGED/HIP only)
+//	then kxp, then hipEstAccum (each an 8-byte float64) for HIP formats
+//
+// followed by the CW stream and then the CSV stream, each CwLengthInts/
+// CsvLengthInts words long. Because the word carrying a given optional
+// field shifts with the format, putSvLengthInts/putWLengthInts/putNumSv/
+// putKxP/putHipAccum/putSvStream/putWStream all take an explicit word
+// index rather than assuming a fixed one; exportToMemory picks the index
+// for each field from the format it is writing.
+const (
+	cpcSerVer   = 1
+	cpcFamilyID = 16 // CPC family ID, as assigned in the Apache DataSketches family registry.
+)
+
+func putByteAt(bytes []byte, byteOffset int, v byte) {
+	bytes[byteOffset] = v
+}
+
+func putInt32At(bytes []byte, wordIndex int, v int32) {
+	binary.LittleEndian.PutUint32(bytes[4*wordIndex:], uint32(v))
+}
+
+func putInt16At(bytes []byte, byteOffset int, v int16) {
+	binary.LittleEndian.PutUint16(bytes[byteOffset:], uint16(v))
+}
+
+func putFloat64At(bytes []byte, wordIndex int, v float64) {
+	binary.LittleEndian.PutUint64(bytes[4*wordIndex:], math.Float64bits(v))
+}
+
+func putIntsAt(bytes []byte, wordIndex int, words []int) {
+	for i, w := range words {
+		putInt32At(bytes, wordIndex+i, int32(w))
+	}
+}
+
+func putPreInts(bytes []byte, preInts int) {
+	putByteAt(bytes, 0, byte(preInts))
+}
+
+func putSerVer(bytes []byte) {
+	putByteAt(bytes, 1, cpcSerVer)
+}
+
+func putFamilyId(bytes []byte) {
+	putByteAt(bytes, 2, cpcFamilyID)
+}
+
+func putLgK(bytes []byte, lgK int) {
+	putByteAt(bytes, 3, byte(lgK))
+}
+
+func putFiCol(bytes []byte, fiCol int) {
+	putByteAt(bytes, 4, byte(fiCol))
+}
+
+func putFormatOrdinal(bytes []byte, ordinal int) {
+	putByteAt(bytes, 5, byte(ordinal))
+}
+
+func putSeedHash(bytes []byte, seedHash int16) {
+	putInt16At(bytes, 6, seedHash)
+}
+
+func putNumCoupons(bytes []byte, numCoupons uint64) {
+	putInt32At(bytes, 2, int32(numCoupons))
+}
+
+// putSvLengthInts, putWLengthInts, putNumSv, putKxP, putHipAccum, putSvStream
+// and putWStream all take an explicit word index because, depending on the
+// format, word 3 onward holds a different subset of these optional fields
+// (see the layout table above) — the caller (exportToMemory) knows which
+// fields the chosen format carries and therefore where each one lands.
+func putSvLengthInts(bytes []byte, wordIndex int, lengthInts int) {
+	putInt32At(bytes, wordIndex, int32(lengthInts))
+}
+
+func putWLengthInts(bytes []byte, wordIndex int, lengthInts int) {
+	putInt32At(bytes, wordIndex, int32(lengthInts))
+}
+
+func putNumSv(bytes []byte, wordIndex int, numSv int) {
+	putInt32At(bytes, wordIndex, int32(numSv))
+}
+
+func putKxP(bytes []byte, wordIndex int, kxp float64) {
+	putFloat64At(bytes, wordIndex, kxp)
+}
+
+func putHipAccum(bytes []byte, wordIndex int, hipEstAccum float64) {
+	putFloat64At(bytes, wordIndex, hipEstAccum)
+}
+
+func putSvStream(bytes []byte, wordIndex int, stream []int) {
+	putIntsAt(bytes, wordIndex, stream)
+}
+
+func putWStream(bytes []byte, wordIndex int, stream []int) {
+	putIntsAt(bytes, wordIndex, stream)
+}
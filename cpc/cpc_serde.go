@@ -0,0 +1,57 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cpc
+
+// LgK returns the log2 of the number of slots the sketch was configured
+// with.
+func (s *CpcSketch) LgK() int {
+	return s.lgK
+}
+
+// SeedHash returns the two-byte hash of the update seed the sketch was
+// constructed with, as stored in its serialized form.
+func (s *CpcSketch) SeedHash() int16 {
+	return s.seedHash
+}
+
+// ToCompactByteArray serializes the sketch to its compressed byte-array
+// form. It is the exported entry point for exportToMemory.
+//
+// The preamble layout this produces matches the reference Apache
+// DataSketches CPC format, but the two payload streams (surprising
+// values and sliding window) are packed with this package's own codec,
+// not the reference implementation's; see WireFormatCompatible. A blob
+// from ToCompactByteArray can only be read back by
+// NewCpcSketchFromCompactByteArray in this package, not by the Java/
+// C++/Python bindings.
+func (s *CpcSketch) ToCompactByteArray() ([]byte, error) {
+	return exportToMemory(s)
+}
+
+// NewCpcSketchFromCompactByteArray reconstructs a sketch previously
+// serialized with ToCompactByteArray in this package (see
+// WireFormatCompatible for why a blob from another language binding
+// will not round-trip here). seed must match the seed the sketch was
+// originally built with.
+func NewCpcSketchFromCompactByteArray(bytes []byte, seed uint64) (*CpcSketch, error) {
+	state, err := importFromMemory(bytes)
+	if err != nil {
+		return nil, err
+	}
+	return state.uncompress(seed)
+}
@@ -0,0 +1,115 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cpc
+
+// pairTable is an open-addressing hash set of coupons, each coupon
+// packing a (row, col) pair as (row<<6)|col. This is the "surprising
+// values" table used by the SPARSE and HYBRID flavors, kept as a hash
+// table (rather than a sorted list) so inserts during normal sketch
+// updates stay O(1).
+type pairTable struct {
+	slots  []uint32
+	lgSize uint
+	count  int
+}
+
+// emptySlot marks an unused slot; it is not a representable coupon since
+// a coupon never uses all 32 bits (row is at most 26 bits, col 6 bits).
+const emptySlot = ^uint32(0)
+
+// newPairTable returns an empty table sized for 1<<lgSize slots.
+func newPairTable(lgSize uint) *pairTable {
+	slots := make([]uint32, 1<<lgSize)
+	for i := range slots {
+		slots[i] = emptySlot
+	}
+	return &pairTable{slots: slots, lgSize: lgSize}
+}
+
+// lgSizeForCount picks a table size that keeps the load factor under
+// roughly 50% for count entries.
+func lgSizeForCount(count int) uint {
+	lg := uint(2)
+	for (1 << lg) < count*2 {
+		lg++
+	}
+	return lg
+}
+
+func couponHash(coupon uint32) uint32 {
+	// Fibonacci hashing: spreads the low bits (which carry most of the
+	// coupon's entropy, since col is only 6 bits) across the table.
+	return (coupon * 2654435761)
+}
+
+func (t *pairTable) slotFor(coupon uint32) int {
+	mask := uint32(1)<<t.lgSize - 1
+	return int(couponHash(coupon) & mask)
+}
+
+// maybeInsert inserts coupon if it is not already present, returning
+// whether it was newly added.
+func (t *pairTable) maybeInsert(coupon uint32) bool {
+	if t.count*2 >= len(t.slots) {
+		t.grow()
+	}
+	mask := len(t.slots) - 1
+	idx := t.slotFor(coupon)
+	for {
+		if t.slots[idx] == emptySlot {
+			t.slots[idx] = coupon
+			t.count++
+			return true
+		}
+		if t.slots[idx] == coupon {
+			return false
+		}
+		idx = (idx + 1) & mask
+	}
+}
+
+func (t *pairTable) grow() {
+	old := t.slots
+	t.lgSize++
+	t.slots = make([]uint32, 1<<t.lgSize)
+	for i := range t.slots {
+		t.slots[i] = emptySlot
+	}
+	t.count = 0
+	for _, c := range old {
+		if c != emptySlot {
+			t.maybeInsert(c)
+		}
+	}
+}
+
+// allCoupons returns every coupon currently stored, in unspecified order.
+func (t *pairTable) allCoupons() []uint32 {
+	coupons := make([]uint32, 0, t.count)
+	for _, c := range t.slots {
+		if c != emptySlot {
+			coupons = append(coupons, c)
+		}
+	}
+	return coupons
+}
+
+// numPairs is the number of distinct coupons currently stored.
+func (t *pairTable) numPairs() int {
+	return t.count
+}
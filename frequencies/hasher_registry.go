@@ -0,0 +1,113 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package frequencies
+
+import (
+	"fmt"
+	"sync"
+)
+
+// hasherRegistry holds every Hasher registered under a name, type-erased
+// since Go generics don't allow a single map keyed by both name and item
+// type; LookupHasher recovers the concrete type with a type assertion.
+type hasherRegistry struct {
+	mu      sync.RWMutex
+	hashers map[string]any
+}
+
+var globalHasherRegistry = &hasherRegistry{hashers: make(map[string]any)}
+
+// RegisterHasher makes h available under name for later lookup with
+// LookupHasher, so a sketch serialized with that name can be
+// reconstituted in another process without the caller importing the
+// specific hash library that produced it.
+func RegisterHasher[T any](name string, h Hasher[T]) {
+	globalHasherRegistry.mu.Lock()
+	defer globalHasherRegistry.mu.Unlock()
+	globalHasherRegistry.hashers[name] = h
+}
+
+// LookupHasher returns the Hasher[T] registered under name. It returns an
+// error if no hasher was registered under that name, or if one was but
+// for a different item type, rather than letting a caller silently hash
+// with the wrong function.
+func LookupHasher[T any](name string) (Hasher[T], error) {
+	globalHasherRegistry.mu.RLock()
+	defer globalHasherRegistry.mu.RUnlock()
+	raw, ok := globalHasherRegistry.hashers[name]
+	if !ok {
+		return nil, fmt.Errorf("frequencies: no hasher registered under name %q", name)
+	}
+	h, ok := raw.(Hasher[T])
+	if !ok {
+		return nil, fmt.Errorf("frequencies: hasher %q is not registered for this item type", name)
+	}
+	return h, nil
+}
+
+// NewItemsSketchWithHasherName constructs an empty ItemsSketch using the
+// Hasher registered under hasherName, so callers can configure a sketch
+// by name (e.g. from config) instead of importing the specific hash
+// library themselves.
+func NewItemsSketchWithHasherName[T comparable](maxMapSize int, hasherName string) (*ItemsSketch[T], error) {
+	hasher, err := LookupHasher[T](hasherName)
+	if err != nil {
+		return nil, err
+	}
+	return NewItemsSketchWithMaxMapSize[T](maxMapSize, hasher)
+}
+
+// EncodeWithHasherName serializes sketch the same way ToSlice does, but
+// prefixes the stream with the hasher's registered name so a reader in
+// another process can recover a matching Hasher via LookupHasher instead
+// of being told out of band which one to use.
+func EncodeWithHasherName[T comparable](sketch *ItemsSketch[T], hasherName string) ([]byte, error) {
+	body, err := sketch.ToSlice()
+	if err != nil {
+		return nil, err
+	}
+	nameBytes := []byte(hasherName)
+	if len(nameBytes) > 255 {
+		return nil, fmt.Errorf("frequencies: hasher name %q longer than 255 bytes", hasherName)
+	}
+	out := make([]byte, 0, 1+len(nameBytes)+len(body))
+	out = append(out, byte(len(nameBytes)))
+	out = append(out, nameBytes...)
+	out = append(out, body...)
+	return out, nil
+}
+
+// DecodeWithHasherName is the inverse of EncodeWithHasherName: it reads
+// the hasher name prefix, looks up a matching Hasher[T] via LookupHasher,
+// and fails loudly rather than silently producing garbage estimates if no
+// hasher is registered under that name.
+func DecodeWithHasherName[T comparable](data []byte) (*ItemsSketch[T], error) {
+	if len(data) < 1 {
+		return nil, fmt.Errorf("frequencies: encoded sketch too short to contain a hasher name")
+	}
+	nameLen := int(data[0])
+	if len(data) < 1+nameLen {
+		return nil, fmt.Errorf("frequencies: encoded sketch truncated before end of hasher name")
+	}
+	name := string(data[1 : 1+nameLen])
+	hasher, err := LookupHasher[T](name)
+	if err != nil {
+		return nil, fmt.Errorf("frequencies: %w (sketch was serialized with hasher %q)", err, name)
+	}
+	return NewItemsSketchFromSlice[T](data[1+nameLen:], hasher)
+}
@@ -0,0 +1,81 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package frequencies
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisterAndLookupHasher(t *testing.T) {
+	h, err := LookupHasher[string](HasherNameMurmur3)
+	require.NoError(t, err)
+	assert.Equal(t, Murmur3StringHasher{}.Hash("x"), h.Hash("x"))
+}
+
+func TestLookupHasherUnknownName(t *testing.T) {
+	_, err := LookupHasher[string]("does-not-exist")
+	assert.Error(t, err)
+}
+
+func TestLookupHasherWrongItemType(t *testing.T) {
+	RegisterHasher[int]("int-only", IntHasher{})
+	_, err := LookupHasher[string]("int-only")
+	assert.Error(t, err)
+}
+
+func TestNewItemsSketchWithHasherName(t *testing.T) {
+	sketch, err := NewItemsSketchWithHasherName[string](1<<_LG_MIN_MAP_SIZE, HasherNameXXH3)
+	require.NoError(t, err)
+	require.NoError(t, sketch.Update("a"))
+	est, err := sketch.GetEstimate("a")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), est)
+}
+
+func TestNewItemsSketchWithHasherNameUnknownName(t *testing.T) {
+	_, err := NewItemsSketchWithHasherName[string](1<<_LG_MIN_MAP_SIZE, "not-registered")
+	assert.Error(t, err)
+}
+
+func TestEncodeDecodeWithHasherNameRoundTrip(t *testing.T) {
+	sketch, err := NewItemsSketchWithHasherName[string](1<<_LG_MIN_MAP_SIZE, HasherNameFNV1a)
+	require.NoError(t, err)
+	require.NoError(t, sketch.Update("a"))
+	require.NoError(t, sketch.Update("b"))
+	require.NoError(t, sketch.Update("a"))
+
+	encoded, err := EncodeWithHasherName[string](sketch, HasherNameFNV1a)
+	require.NoError(t, err)
+
+	restored, err := DecodeWithHasherName[string](encoded)
+	require.NoError(t, err)
+	assert.Equal(t, sketch.GetStreamLength(), restored.GetStreamLength())
+}
+
+func TestDecodeWithHasherNameFailsLoudlyOnUnknownHasher(t *testing.T) {
+	sketch, err := NewItemsSketchWithHasherName[string](1<<_LG_MIN_MAP_SIZE, HasherNameFNV1a)
+	require.NoError(t, err)
+	encoded, err := EncodeWithHasherName[string](sketch, "not-registered")
+	require.NoError(t, err)
+
+	_, err = DecodeWithHasherName[string](encoded)
+	assert.Error(t, err)
+}
@@ -0,0 +1,69 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package frequencies
+
+import (
+	"hash/fnv"
+	"unsafe"
+
+	"github.com/apache/datasketches-go/internal"
+	"github.com/twmb/murmur3"
+	"github.com/zeebo/xxh3"
+)
+
+// Names under which the built-in string hashers are registered, for use
+// with NewItemsSketchWithHasherName and EncodeWithHasherName.
+const (
+	HasherNameMurmur3 = "murmur3-64"
+	HasherNameXXH3    = "xxh3-64"
+	HasherNameFNV1a   = "fnv1a-64"
+)
+
+// Murmur3StringHasher hashes strings with the seeded murmur3
+// implementation this package has historically used as its default.
+type Murmur3StringHasher struct{}
+
+func (Murmur3StringHasher) Hash(item string) uint64 {
+	datum := unsafe.Slice(unsafe.StringData(item), len(item))
+	return murmur3.SeedSum64(internal.DEFAULT_UPDATE_SEED, datum)
+}
+
+// XXH3StringHasher hashes strings with xxh3-64, a faster alternative to
+// murmur3 for producers that don't need cross-version hash stability.
+type XXH3StringHasher struct{}
+
+func (XXH3StringHasher) Hash(item string) uint64 {
+	return xxh3.HashString(item)
+}
+
+// FNV1aStringHasher hashes strings with the standard library's 64-bit
+// FNV-1a, for interop with producers in languages that ship FNV but
+// neither murmur3 nor xxh3.
+type FNV1aStringHasher struct{}
+
+func (FNV1aStringHasher) Hash(item string) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(item))
+	return h.Sum64()
+}
+
+func init() {
+	RegisterHasher[string](HasherNameMurmur3, Murmur3StringHasher{})
+	RegisterHasher[string](HasherNameXXH3, XXH3StringHasher{})
+	RegisterHasher[string](HasherNameFNV1a, FNV1aStringHasher{})
+}
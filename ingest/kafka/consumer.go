@@ -0,0 +1,341 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package kafkaingest feeds a Kafka topic into a Frequencies and/or CPC
+// sketch, snapshotting the sketch state and the Kafka offsets it reflects
+// together so a restart resumes without double-counting.
+package kafkaingest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/IBM/sarama"
+
+	"github.com/apache/datasketches-go/cpc"
+	"github.com/apache/datasketches-go/frequencies"
+	"github.com/apache/datasketches-go/sketchstore"
+)
+
+// Decoder turns a raw Kafka message value into the item type the sketches
+// track.
+type Decoder[T any] func([]byte) (T, error)
+
+// offsets maps topic -> partition -> next offset to consume, i.e. one past
+// the last offset that has been folded into the sketches.
+type offsets map[string]map[int32]int64
+
+// Consumer folds every message on the given topics into a Frequencies
+// sketch and a CPC sketch, periodically snapshotting both sketches and
+// the offsets they reflect under a single Store prefix so that on restart the
+// snapshot and the resumed Kafka offsets always agree.
+type Consumer[T comparable] struct {
+	group   sarama.ConsumerGroup
+	topics  []string
+	decoder Decoder[T]
+
+	store          sketchstore.Store
+	snapshotPrefix string
+	snapshotEvery  int
+	hasher         frequencies.Hasher[T]
+	seed           uint64
+
+	mu          sync.Mutex
+	freq        *frequencies.ItemsSketch[T]
+	cpcSketch   *cpc.CpcSketch
+	offsets     offsets
+	sinceFlush  int
+	streamCount int64
+}
+
+// Config bundles everything needed to construct a Consumer.
+type Config[T comparable] struct {
+	ConsumerGroup  sarama.ConsumerGroup
+	Topics         []string
+	Decoder        Decoder[T]
+	Frequencies    *frequencies.ItemsSketch[T]
+	Cpc            *cpc.CpcSketch
+	Store          sketchstore.Store
+	SnapshotPrefix string
+	// SnapshotEvery is how many processed messages elapse between
+	// snapshot+commit cycles.
+	SnapshotEvery int
+	// Hasher must be set whenever Frequencies is, so Restore can
+	// reconstruct a sketch from a stored frequencies.bin blob via
+	// frequencies.NewItemsSketchFromSlice.
+	Hasher frequencies.Hasher[T]
+	// Seed must be set whenever Cpc is, so Restore can reconstruct a
+	// sketch from a stored cpc.bin blob via
+	// cpc.NewCpcSketchFromCompactByteArray.
+	Seed uint64
+}
+
+// snapshotState is the offsets.json sidecar written on every flush after
+// the two sketch blobs: the offsets those blobs reflect and the stream
+// count, so Restore can bring sketches and offsets back consistently.
+type snapshotState struct {
+	Offsets     offsets `json:"offsets"`
+	StreamCount int64   `json:"stream_count"`
+}
+
+// NewConsumer constructs a Consumer from cfg.
+func NewConsumer[T comparable](cfg Config[T]) (*Consumer[T], error) {
+	if cfg.SnapshotEvery <= 0 {
+		cfg.SnapshotEvery = 1000
+	}
+	return &Consumer[T]{
+		group:          cfg.ConsumerGroup,
+		topics:         cfg.Topics,
+		decoder:        cfg.Decoder,
+		store:          cfg.Store,
+		snapshotPrefix: cfg.SnapshotPrefix,
+		snapshotEvery:  cfg.SnapshotEvery,
+		hasher:         cfg.Hasher,
+		seed:           cfg.Seed,
+		freq:           cfg.Frequencies,
+		cpcSketch:      cfg.Cpc,
+		offsets:        offsets{},
+	}, nil
+}
+
+// Restore loads the latest snapshot (if any) before consumption starts,
+// reconstructing the Frequencies/CPC sketches from the frequencies.bin/
+// cpc.bin blobs flush wrote alongside the offsets, so GetStreamLength and
+// the sketch contents reflect every offset that was committed, and
+// nothing more.
+func (c *Consumer[T]) Restore(ctx context.Context) error {
+	data, err := c.store.Get(ctx, c.snapshotPrefix+"/offsets.json")
+	if err == sketchstore.ErrNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var state snapshotState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return err
+	}
+
+	var freq *frequencies.ItemsSketch[T]
+	if c.freq != nil {
+		if c.hasher == nil {
+			return fmt.Errorf("kafkaingest: Config.Hasher must be set to restore a frequencies snapshot")
+		}
+		freqData, err := c.store.Get(ctx, c.snapshotPrefix+"/frequencies.bin")
+		if err != nil {
+			return err
+		}
+		freq, err = frequencies.NewItemsSketchFromSlice[T](freqData, c.hasher)
+		if err != nil {
+			return err
+		}
+	}
+
+	var cpcSketch *cpc.CpcSketch
+	if c.cpcSketch != nil {
+		cpcData, err := c.store.Get(ctx, c.snapshotPrefix+"/cpc.bin")
+		if err != nil {
+			return err
+		}
+		cpcSketch, err = cpc.NewCpcSketchFromCompactByteArray(cpcData, c.seed)
+		if err != nil {
+			return err
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if freq != nil {
+		c.freq = freq
+	}
+	if cpcSketch != nil {
+		c.cpcSketch = cpcSketch
+	}
+	c.offsets = state.Offsets
+	c.streamCount = state.StreamCount
+	return nil
+}
+
+// Run joins the consumer group and blocks, folding messages into the
+// sketches until ctx is canceled.
+func (c *Consumer[T]) Run(ctx context.Context) error {
+	for {
+		if err := c.group.Consume(ctx, c.topics, c); err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+}
+
+// Setup implements sarama.ConsumerGroupHandler. It seeks every claimed
+// partition to the offset recorded in the last snapshot, if any, so
+// already-folded messages are not replayed.
+func (c *Consumer[T]) Setup(session sarama.ConsumerGroupSession) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for topic, partitionOffsets := range c.offsets {
+		for partition, offset := range partitionOffsets {
+			session.ResetOffset(topic, partition, offset, "")
+		}
+	}
+	return nil
+}
+
+// Cleanup implements sarama.ConsumerGroupHandler.
+func (c *Consumer[T]) Cleanup(sarama.ConsumerGroupSession) error { return nil }
+
+// ConsumeClaim implements sarama.ConsumerGroupHandler.
+func (c *Consumer[T]) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	for {
+		select {
+		case <-session.Context().Done():
+			return nil
+		case msg, ok := <-claim.Messages():
+			if !ok {
+				return nil
+			}
+			if err := c.applyMessage(msg.Topic, msg.Partition, msg.Offset, msg.Value); err != nil {
+				return err
+			}
+			session.MarkMessage(msg, "")
+			if c.shouldFlush() {
+				if err := c.flush(session.Context()); err != nil {
+					return err
+				}
+				session.Commit()
+			}
+		}
+	}
+}
+
+// applyMessage decodes value and folds it into both sketches. It is the
+// core per-message logic, kept free of any sarama types so it can be unit
+// tested directly.
+func (c *Consumer[T]) applyMessage(topic string, partition int32, offset int64, value []byte) error {
+	item, err := c.decoder(value)
+	if err != nil {
+		return fmt.Errorf("kafkaingest: decode message at %s[%d]@%d: %w", topic, partition, offset, err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.freq != nil {
+		if err := c.freq.Update(item); err != nil {
+			return err
+		}
+	}
+	if c.cpcSketch != nil {
+		if err := c.cpcSketch.Update(item); err != nil {
+			return err
+		}
+	}
+	if c.offsets[topic] == nil {
+		c.offsets[topic] = map[int32]int64{}
+	}
+	c.offsets[topic][partition] = offset + 1
+	c.streamCount++
+	c.sinceFlush++
+	return nil
+}
+
+func (c *Consumer[T]) shouldFlush() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.sinceFlush >= c.snapshotEvery
+}
+
+// flush snapshots both sketches and the offsets they reflect. The offsets
+// sidecar is written last, after both sketch blobs have landed, so a
+// reader never observes offsets that are ahead of the sketch data; the
+// corresponding Kafka offset commit only happens after flush returns
+// successfully.
+func (c *Consumer[T]) flush(ctx context.Context) error {
+	c.mu.Lock()
+	freqSketch := c.freq
+	cpcSketch := c.cpcSketch
+	state := snapshotState{Offsets: copyOffsets(c.offsets), StreamCount: c.streamCount}
+	c.sinceFlush = 0
+	c.mu.Unlock()
+
+	if freqSketch != nil {
+		data, err := freqSketch.ToSlice()
+		if err != nil {
+			return err
+		}
+		if err := c.store.Put(ctx, c.snapshotPrefix+"/frequencies.bin", data); err != nil {
+			return err
+		}
+	}
+	if cpcSketch != nil {
+		data, err := cpcSketch.ToCompactByteArray()
+		if err != nil {
+			return err
+		}
+		if err := c.store.Put(ctx, c.snapshotPrefix+"/cpc.bin", data); err != nil {
+			return err
+		}
+	}
+	stateBytes, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	return c.store.Put(ctx, c.snapshotPrefix+"/offsets.json", stateBytes)
+}
+
+// GetStreamLength returns the number of messages folded into the sketches
+// so far, including any restored from a prior snapshot.
+func (c *Consumer[T]) GetStreamLength() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.streamCount
+}
+
+// GetEstimate returns the Frequencies estimate for item.
+func (c *Consumer[T]) GetEstimate(item T) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.freq == nil {
+		return 0, fmt.Errorf("kafkaingest: no frequencies sketch configured")
+	}
+	return c.freq.GetEstimate(item)
+}
+
+// GetFrequentItems returns the Frequencies sketch's frequent items.
+func (c *Consumer[T]) GetFrequentItems(errorType frequencies.ErrorType) ([]*frequencies.Row[T], error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.freq == nil {
+		return nil, fmt.Errorf("kafkaingest: no frequencies sketch configured")
+	}
+	return c.freq.GetFrequentItems(errorType)
+}
+
+func copyOffsets(o offsets) offsets {
+	cp := make(offsets, len(o))
+	for topic, partitions := range o {
+		cpPartitions := make(map[int32]int64, len(partitions))
+		for p, off := range partitions {
+			cpPartitions[p] = off
+		}
+		cp[topic] = cpPartitions
+	}
+	return cp
+}
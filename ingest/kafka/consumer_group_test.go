@@ -0,0 +1,247 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package kafkaingest
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/IBM/sarama"
+	"github.com/IBM/sarama/mocks"
+	"github.com/stretchr/testify/require"
+
+	"github.com/apache/datasketches-go/cpc"
+	"github.com/apache/datasketches-go/frequencies"
+	"github.com/apache/datasketches-go/internal"
+	"github.com/apache/datasketches-go/sketchstore"
+)
+
+// fakeConsumerGroupSession implements sarama.ConsumerGroupSession, recording
+// the calls Consumer makes to it. sarama/mocks has no ConsumerGroup broker
+// simulation (group coordination is normally integration-tested against a
+// real cluster), so this plays that part while the message stream itself
+// comes from a real mocks.Consumer below.
+type fakeConsumerGroupSession struct {
+	ctx context.Context
+
+	mu      sync.Mutex
+	resets  []resetOffsetCall
+	marked  []*sarama.ConsumerMessage
+	commits int
+}
+
+type resetOffsetCall struct {
+	topic     string
+	partition int32
+	offset    int64
+}
+
+func newFakeConsumerGroupSession(ctx context.Context) *fakeConsumerGroupSession {
+	return &fakeConsumerGroupSession{ctx: ctx}
+}
+
+func (s *fakeConsumerGroupSession) Claims() map[string][]int32 { return nil }
+func (s *fakeConsumerGroupSession) MemberID() string           { return "test-member" }
+func (s *fakeConsumerGroupSession) GenerationID() int32        { return 1 }
+
+func (s *fakeConsumerGroupSession) MarkOffset(topic string, partition int32, offset int64, metadata string) {
+}
+
+func (s *fakeConsumerGroupSession) ResetOffset(topic string, partition int32, offset int64, metadata string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.resets = append(s.resets, resetOffsetCall{topic, partition, offset})
+}
+
+func (s *fakeConsumerGroupSession) MarkMessage(msg *sarama.ConsumerMessage, metadata string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.marked = append(s.marked, msg)
+}
+
+func (s *fakeConsumerGroupSession) Commit() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.commits++
+}
+
+func (s *fakeConsumerGroupSession) Context() context.Context { return s.ctx }
+
+// fakeConsumerGroupClaim implements sarama.ConsumerGroupClaim over a channel
+// fed from a real mocks.PartitionConsumer, so ConsumeClaim sees the same
+// *sarama.ConsumerMessage values a mock broker produced.
+type fakeConsumerGroupClaim struct {
+	topic     string
+	partition int32
+	ch        chan *sarama.ConsumerMessage
+}
+
+func (c *fakeConsumerGroupClaim) Topic() string                            { return c.topic }
+func (c *fakeConsumerGroupClaim) Partition() int32                         { return c.partition }
+func (c *fakeConsumerGroupClaim) InitialOffset() int64                     { return 0 }
+func (c *fakeConsumerGroupClaim) HighWaterMarkOffset() int64               { return 0 }
+func (c *fakeConsumerGroupClaim) Messages() <-chan *sarama.ConsumerMessage { return c.ch }
+
+// runClaim feeds batch through a mocks.Consumer partition consumer and into
+// a real Consumer.Setup/ConsumeClaim call, the way Run would via
+// sarama.ConsumerGroup.Consume, and returns once every message has been
+// marked (ConsumeClaim sees the claim channel close and returns).
+func runClaim[T comparable](t *testing.T, c *Consumer[T], topic string, partition int32, batch []*sarama.ConsumerMessage) *fakeConsumerGroupSession {
+	t.Helper()
+
+	mc := mocks.NewConsumer(t, sarama.NewConfig())
+	defer func() { require.NoError(t, mc.Close()) }()
+
+	exp := mc.ExpectConsumePartition(topic, partition, sarama.OffsetOldest)
+	for _, msg := range batch {
+		exp.YieldMessage(msg)
+	}
+	pc, err := mc.ConsumePartition(topic, partition, sarama.OffsetOldest)
+	require.NoError(t, err)
+	defer func() { require.NoError(t, pc.Close()) }()
+
+	session := newFakeConsumerGroupSession(context.Background())
+	require.NoError(t, c.Setup(session))
+
+	claim := &fakeConsumerGroupClaim{topic: topic, partition: partition, ch: make(chan *sarama.ConsumerMessage)}
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(claim.ch)
+		for i := 0; i < len(batch); i++ {
+			claim.ch <- <-pc.Messages()
+		}
+	}()
+
+	require.NoError(t, c.ConsumeClaim(session, claim))
+	wg.Wait()
+	return session
+}
+
+func yield(topic string, partition int32, startOffset int64, values []string) []*sarama.ConsumerMessage {
+	out := make([]*sarama.ConsumerMessage, len(values))
+	for i, v := range values {
+		out[i] = &sarama.ConsumerMessage{
+			Topic:     topic,
+			Partition: partition,
+			Offset:    startOffset + int64(i),
+			Value:     []byte(v),
+		}
+	}
+	return out
+}
+
+// TestConsumeClaimCrashAndRestart drives Consumer through the real
+// ConsumerGroupHandler methods (Setup/ConsumeClaim/MarkMessage/Commit)
+// against message batches produced by a sarama/mocks broker, for both the
+// Frequencies and CPC ingestion paths: fold half a stream, flush+commit,
+// "crash", restore into a fresh Consumer backed by the same store, and fold
+// the rest, checking the result matches an uninterrupted run.
+func TestConsumeClaimCrashAndRestart(t *testing.T) {
+	const topic = "clicks"
+	const seed = internal.DEFAULT_UPDATE_SEED
+	stream := []string{"a", "b", "a", "c", "b", "a", "d", "a", "c", "b"}
+
+	newConsumer := func(t *testing.T, store sketchstore.Store, withFreq, withCpc bool) *Consumer[string] {
+		t.Helper()
+		cfg := Config[string]{
+			Topics:         []string{topic},
+			Decoder:        decodeString,
+			Store:          store,
+			SnapshotPrefix: "clicks-consumer",
+			SnapshotEvery:  mid(stream),
+		}
+		if withFreq {
+			freq, err := frequencies.NewItemsSketchWithMaxMapSize[string](1<<8, stringHasher{})
+			require.NoError(t, err)
+			cfg.Frequencies = freq
+			cfg.Hasher = stringHasher{}
+		}
+		if withCpc {
+			sketch, err := cpc.NewCpcSketch(4, seed)
+			require.NoError(t, err)
+			cfg.Cpc = sketch
+			cfg.Seed = seed
+		}
+		c, err := NewConsumer(cfg)
+		require.NoError(t, err)
+		return c
+	}
+
+	cases := []struct {
+		name string
+		freq bool
+		cpc  bool
+	}{
+		{"frequencies_only", true, false},
+		{"cpc_only", false, true},
+		{"both", true, true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			uninterrupted := newConsumer(t, sketchstore.NewMemoryStore(), tc.freq, tc.cpc)
+			runClaim(t, uninterrupted, topic, 0, yield(topic, 0, 0, stream))
+
+			store := sketchstore.NewMemoryStore()
+			first := newConsumer(t, store, tc.freq, tc.cpc)
+			firstSession := runClaim(t, first, topic, 0, yield(topic, 0, 0, stream[:mid(stream)]))
+			require.GreaterOrEqual(t, firstSession.commits, 1, "crossing SnapshotEvery inside ConsumeClaim must flush and commit")
+
+			// "Restart": a brand new Consumer backed by the same store picks
+			// up exactly where the crashed one left off, including seeking
+			// the resumed claim's offset via Setup/ResetOffset.
+			second := newConsumer(t, store, tc.freq, tc.cpc)
+			require.NoError(t, second.Restore(ctx))
+			require.Equal(t, int64(mid(stream)), second.GetStreamLength())
+			secondSession := runClaim(t, second, topic, 0, yield(topic, 0, int64(mid(stream)), stream[mid(stream):]))
+			require.Len(t, secondSession.resets, 1)
+			require.Equal(t, int64(mid(stream)), secondSession.resets[0].offset)
+
+			require.Equal(t, uninterrupted.GetStreamLength(), second.GetStreamLength())
+			if tc.freq {
+				for _, item := range []string{"a", "b", "c", "d"} {
+					want, err := uninterrupted.GetEstimate(item)
+					require.NoError(t, err)
+					got, err := second.GetEstimate(item)
+					require.NoError(t, err)
+					require.Equalf(t, want, got, "frequencies estimate for %q diverged after restart", item)
+				}
+			}
+			if tc.cpc {
+				wantEst, err := uninterrupted.cpcSketch.GetEstimate()
+				require.NoError(t, err)
+				gotEst, err := second.cpcSketch.GetEstimate()
+				require.NoError(t, err)
+				require.InDelta(t, wantEst, gotEst, 0.0001, "cpc estimate diverged after restart")
+			}
+		})
+	}
+}
+
+// mid picks a split point that is also a multiple of the consumer's
+// SnapshotEvery, so the "crash" in TestConsumeClaimCrashAndRestart always
+// lands exactly on a flushed+committed boundary instead of discarding the
+// last partial batch the way a real mid-batch crash would.
+func mid(stream []string) int {
+	return len(stream) / 2
+}
@@ -0,0 +1,130 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package kafkaingest
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"unsafe"
+
+	"github.com/apache/datasketches-go/frequencies"
+	"github.com/apache/datasketches-go/internal"
+	"github.com/apache/datasketches-go/sketchstore"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/murmur3"
+)
+
+type stringHasher struct{}
+
+func (stringHasher) Hash(item string) uint64 {
+	datum := unsafe.Slice(unsafe.StringData(item), len(item))
+	return murmur3.SeedSum64(internal.DEFAULT_UPDATE_SEED, datum[:])
+}
+
+func decodeString(b []byte) (string, error) { return string(b), nil }
+
+func newTestConsumer(t *testing.T, store sketchstore.Store) *Consumer[string] {
+	t.Helper()
+	freq, err := frequencies.NewItemsSketchWithMaxMapSize[string](1<<8, stringHasher{})
+	require.NoError(t, err)
+	c, err := NewConsumer(Config[string]{
+		Topics:         []string{"clicks"},
+		Decoder:        decodeString,
+		Frequencies:    freq,
+		Hasher:         stringHasher{},
+		Store:          store,
+		SnapshotPrefix: "clicks-consumer",
+		SnapshotEvery:  1 << 30, // flush only when the test asks for it
+	})
+	require.NoError(t, err)
+	return c
+}
+
+// messages mimics a partition's worth of records at increasing offsets.
+func messages(values ...string) []struct {
+	offset int64
+	value  string
+} {
+	out := make([]struct {
+		offset int64
+		value  string
+	}, len(values))
+	for i, v := range values {
+		out[i] = struct {
+			offset int64
+			value  string
+		}{offset: int64(i), value: v}
+	}
+	return out
+}
+
+// TestMidStreamCrashAndRestartMatchesUninterruptedRun verifies the
+// exactly-once-per-offset contract: folding the full stream in one
+// uninterrupted run produces the same estimates as folding the first half,
+// snapshotting (simulating a crash), restoring into a fresh Consumer, and
+// folding the remainder.
+func TestMidStreamCrashAndRestartMatchesUninterruptedRun(t *testing.T) {
+	ctx := context.Background()
+	stream := []string{"a", "b", "a", "c", "b", "a", "d", "a", "c", "b"}
+
+	uninterrupted := newTestConsumer(t, sketchstore.NewMemoryStore())
+	for i, v := range stream {
+		require.NoError(t, uninterrupted.applyMessage("clicks", 0, int64(i), []byte(v)))
+	}
+
+	store := sketchstore.NewMemoryStore()
+	first := newTestConsumer(t, store)
+	mid := len(stream) / 2
+	for i := 0; i < mid; i++ {
+		require.NoError(t, first.applyMessage("clicks", 0, int64(i), []byte(stream[i])))
+	}
+	require.NoError(t, first.flush(ctx))
+
+	// "Restart": a brand new Consumer backed by the same store resumes
+	// exactly where the crashed one left off.
+	second := newTestConsumer(t, store)
+	require.NoError(t, second.Restore(ctx))
+	require.Equal(t, int64(mid), second.GetStreamLength())
+	for i := mid; i < len(stream); i++ {
+		require.NoError(t, second.applyMessage("clicks", 0, int64(i), []byte(stream[i])))
+	}
+
+	require.Equal(t, uninterrupted.GetStreamLength(), second.GetStreamLength())
+	for _, item := range []string{"a", "b", "c", "d"} {
+		want, err := uninterrupted.GetEstimate(item)
+		require.NoError(t, err)
+		got, err := second.GetEstimate(item)
+		require.NoError(t, err)
+		require.Equalf(t, want, got, "estimate for %q diverged after restart", item)
+	}
+}
+
+func TestRestoreWithNoPriorSnapshotIsNoop(t *testing.T) {
+	c := newTestConsumer(t, sketchstore.NewMemoryStore())
+	require.NoError(t, c.Restore(context.Background()))
+	require.Equal(t, int64(0), c.GetStreamLength())
+}
+
+func TestApplyMessageTracksOffsetsPerPartition(t *testing.T) {
+	c := newTestConsumer(t, sketchstore.NewMemoryStore())
+	for i := 0; i < 5; i++ {
+		require.NoError(t, c.applyMessage("clicks", 2, int64(i), []byte(fmt.Sprintf("v%d", i))))
+	}
+	require.Equal(t, int64(5), c.offsets["clicks"][2])
+}
@@ -0,0 +1,61 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package kafkaingest
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/apache/datasketches-go/frequencies"
+)
+
+// Handler exposes the Consumer's sketches for live querying over HTTP:
+//
+//	GET /estimate?item=foo  -> {"estimate":42,"item":"foo"}
+//	GET /frequent-items     -> the current frequent-item list
+func Handler[T comparable](c *Consumer[T], parseItem func(string) (T, error)) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/estimate", func(w http.ResponseWriter, r *http.Request) {
+		raw := r.URL.Query().Get("item")
+		item, err := parseItem(raw)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		est, err := c.GetEstimate(item)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, map[string]any{"item": raw, "estimate": est})
+	})
+	mux.HandleFunc("/frequent-items", func(w http.ResponseWriter, r *http.Request) {
+		items, err := c.GetFrequentItems(frequencies.ErrorTypeEnum.NoFalsePositives)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, items)
+	})
+	return mux
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
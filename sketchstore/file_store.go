@@ -0,0 +1,90 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sketchstore
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FileStore is a Store backed by a directory on the local filesystem. Keys
+// are mapped directly to paths relative to Root.
+type FileStore struct {
+	Root string
+}
+
+// NewFileStore returns a FileStore rooted at root, creating it if it does
+// not already exist.
+func NewFileStore(root string) (*FileStore, error) {
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, err
+	}
+	return &FileStore{Root: root}, nil
+}
+
+func (f *FileStore) path(key string) string {
+	return filepath.Join(f.Root, filepath.FromSlash(key))
+}
+
+func (f *FileStore) Put(ctx context.Context, key string, data []byte) error {
+	p := f.path(key)
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(p, data, 0o644)
+}
+
+func (f *FileStore) Get(ctx context.Context, key string) ([]byte, error) {
+	data, err := os.ReadFile(f.path(key))
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	return data, err
+}
+
+func (f *FileStore) List(ctx context.Context, prefix string) ([]string, error) {
+	var keys []string
+	err := filepath.WalkDir(f.Root, func(p string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(f.Root, p)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+		return nil
+	})
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return keys, err
+}
+
+func (f *FileStore) Delete(ctx context.Context, key string) error {
+	err := os.Remove(f.path(key))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,71 @@
+//go:build integration
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// This file exercises S3Store against a real MinIO server started via
+// testcontainers-go. It is excluded from the default build/test run
+// (requires Docker) and only compiled with -tags=integration.
+package sketchstore
+
+import (
+	"context"
+	"testing"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	miniocontainer "github.com/testcontainers/testcontainers-go/modules/minio"
+)
+
+func TestS3StoreRoundTripAgainstRealMinio(t *testing.T) {
+	ctx := context.Background()
+
+	container, err := miniocontainer.Run(ctx, "minio/minio:latest")
+	if err != nil {
+		t.Fatalf("start minio container: %v", err)
+	}
+	defer func() { _ = container.Terminate(ctx) }()
+
+	endpoint, err := container.ConnectionString(ctx)
+	if err != nil {
+		t.Fatalf("connection string: %v", err)
+	}
+
+	admin, err := minio.New(endpoint, &minio.Options{
+		Creds: credentials.NewStaticV4(container.Username, container.Password, ""),
+	})
+	if err != nil {
+		t.Fatalf("new admin client: %v", err)
+	}
+	const bucket = "sketchstore-it"
+	if err := admin.MakeBucket(ctx, bucket, minio.MakeBucketOptions{}); err != nil {
+		t.Fatalf("make bucket: %v", err)
+	}
+
+	store, err := NewS3Store(S3Config{
+		Endpoint:  endpoint,
+		AccessKey: container.Username,
+		SecretKey: container.Password,
+		Bucket:    bucket,
+		Secure:    false,
+	})
+	if err != nil {
+		t.Fatalf("new S3Store: %v", err)
+	}
+
+	testStoreRoundTrip(t, store)
+}
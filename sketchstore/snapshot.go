@@ -0,0 +1,201 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sketchstore
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"path"
+	"time"
+
+	"github.com/apache/datasketches-go/cpc"
+	"github.com/apache/datasketches-go/frequencies"
+)
+
+// snapshotMetadata is the JSON sidecar written alongside every snapshot
+// blob so a Loader can validate it before deserializing.
+type snapshotMetadata struct {
+	Format      string    `json:"format"`
+	ContentHash string    `json:"content_hash"`
+	LgK         int       `json:"lg_k,omitempty"`
+	SeedHash    int16     `json:"seed_hash,omitempty"`
+	SavedAt     time.Time `json:"saved_at"`
+}
+
+func snapshotKeys(prefix string) (blobKey, metaKey string) {
+	return path.Join(prefix, "snapshot.bin"), path.Join(prefix, "snapshot.meta.json")
+}
+
+func contentHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func putSnapshot(ctx context.Context, store Store, prefix string, data []byte, meta snapshotMetadata) error {
+	blobKey, metaKey := snapshotKeys(prefix)
+	meta.ContentHash = contentHash(data)
+	meta.SavedAt = time.Now().UTC()
+	metaBytes, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	if err := store.Put(ctx, blobKey, data); err != nil {
+		return err
+	}
+	return store.Put(ctx, metaKey, metaBytes)
+}
+
+// getSnapshot fetches the snapshot blob and its metadata sidecar at prefix
+// and validates the blob against the sidecar's content hash, so a loader
+// never deserializes a blob that doesn't match the metadata it was saved
+// with (e.g. a partially overwritten blob, or a blob and sidecar from two
+// different snapshots).
+func getSnapshot(ctx context.Context, store Store, prefix string) (data []byte, meta snapshotMetadata, err error) {
+	blobKey, metaKey := snapshotKeys(prefix)
+	data, err = store.Get(ctx, blobKey)
+	if err != nil {
+		return nil, meta, err
+	}
+	metaBytes, err := store.Get(ctx, metaKey)
+	if err != nil {
+		return nil, meta, err
+	}
+	if err := json.Unmarshal(metaBytes, &meta); err != nil {
+		return nil, meta, err
+	}
+	if got := contentHash(data); got != meta.ContentHash {
+		return nil, meta, fmt.Errorf("sketchstore: snapshot at %q failed content hash check: metadata says %s, blob hashes to %s", prefix, meta.ContentHash, got)
+	}
+	return data, meta, nil
+}
+
+// CpcSnapshotter periodically serializes a CpcSketch to a Store.
+type CpcSnapshotter struct {
+	Store  Store
+	Prefix string
+}
+
+// Snapshot writes the current state of sketch to the store.
+func (s *CpcSnapshotter) Snapshot(ctx context.Context, sketch *cpc.CpcSketch) error {
+	data, err := sketch.ToCompactByteArray()
+	if err != nil {
+		return err
+	}
+	return putSnapshot(ctx, s.Store, s.Prefix, data, snapshotMetadata{
+		Format:   "cpc",
+		LgK:      sketch.LgK(),
+		SeedHash: sketch.SeedHash(),
+	})
+}
+
+// Run calls Snapshot on the given interval until ctx is done.
+func (s *CpcSnapshotter) Run(ctx context.Context, sketch *cpc.CpcSketch, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			if err := s.Snapshot(ctx, sketch); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// CpcLoader streams the latest CPC snapshot from a Store back into a live
+// sketch, e.g. on process startup.
+type CpcLoader struct {
+	Store  Store
+	Prefix string
+}
+
+// Load fetches the latest snapshot and reconstitutes it using seed, then
+// checks the result against the lgK and seed hash recorded in the metadata
+// sidecar, so a caller who passes the wrong seed gets an error instead of a
+// silently mis-decoded sketch.
+func (l *CpcLoader) Load(ctx context.Context, seed uint64) (*cpc.CpcSketch, error) {
+	data, meta, err := getSnapshot(ctx, l.Store, l.Prefix)
+	if err != nil {
+		return nil, err
+	}
+	sketch, err := cpc.NewCpcSketchFromCompactByteArray(data, seed)
+	if err != nil {
+		return nil, err
+	}
+	if sketch.LgK() != meta.LgK {
+		return nil, fmt.Errorf("sketchstore: snapshot at %q has lgK %d, metadata says %d", l.Prefix, sketch.LgK(), meta.LgK)
+	}
+	if sketch.SeedHash() != meta.SeedHash {
+		return nil, fmt.Errorf("sketchstore: snapshot at %q has seed hash %d, metadata says %d", l.Prefix, sketch.SeedHash(), meta.SeedHash)
+	}
+	return sketch, nil
+}
+
+// FrequenciesSnapshotter periodically serializes an ItemsSketch to a
+// Store.
+type FrequenciesSnapshotter[T comparable] struct {
+	Store  Store
+	Prefix string
+}
+
+// Snapshot writes the current state of sketch to the store.
+func (s *FrequenciesSnapshotter[T]) Snapshot(ctx context.Context, sketch *frequencies.ItemsSketch[T]) error {
+	data, err := sketch.ToSlice()
+	if err != nil {
+		return err
+	}
+	return putSnapshot(ctx, s.Store, s.Prefix, data, snapshotMetadata{Format: "frequencies"})
+}
+
+// Run calls Snapshot on the given interval until ctx is done.
+func (s *FrequenciesSnapshotter[T]) Run(ctx context.Context, sketch *frequencies.ItemsSketch[T], interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			if err := s.Snapshot(ctx, sketch); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// FrequenciesLoader streams the latest Frequencies snapshot from a Store
+// back into a live sketch.
+type FrequenciesLoader[T comparable] struct {
+	Store  Store
+	Prefix string
+}
+
+// Load fetches the latest snapshot and reconstitutes it using hasher to
+// recompute item hashes.
+func (l *FrequenciesLoader[T]) Load(ctx context.Context, hasher frequencies.Hasher[T]) (*frequencies.ItemsSketch[T], error) {
+	data, _, err := getSnapshot(ctx, l.Store, l.Prefix)
+	if err != nil {
+		return nil, err
+	}
+	return frequencies.NewItemsSketchFromSlice[T](data, hasher)
+}
@@ -0,0 +1,106 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sketchstore
+
+import (
+	"context"
+	"testing"
+	"unsafe"
+
+	"github.com/apache/datasketches-go/cpc"
+	"github.com/apache/datasketches-go/frequencies"
+	"github.com/apache/datasketches-go/internal"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/murmur3"
+)
+
+type testStringHasher struct{}
+
+func (testStringHasher) Hash(item string) uint64 {
+	datum := unsafe.Slice(unsafe.StringData(item), len(item))
+	return murmur3.SeedSum64(internal.DEFAULT_UPDATE_SEED, datum[:])
+}
+
+func TestCpcSnapshotRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	const seed = internal.DEFAULT_UPDATE_SEED
+
+	sketch, err := cpc.NewCpcSketch(11, seed)
+	require.NoError(t, err)
+	for i := 0; i < 1000; i++ {
+		require.NoError(t, sketch.UpdateUint64(uint64(i)))
+	}
+
+	store := NewMemoryStore()
+	snapshotter := &CpcSnapshotter{Store: store, Prefix: "cpc/demo"}
+	require.NoError(t, snapshotter.Snapshot(ctx, sketch))
+
+	loader := &CpcLoader{Store: store, Prefix: "cpc/demo"}
+	restored, err := loader.Load(ctx, seed)
+	require.NoError(t, err)
+
+	wantEst, err := sketch.GetEstimate()
+	require.NoError(t, err)
+	gotEst, err := restored.GetEstimate()
+	require.NoError(t, err)
+	require.InDelta(t, wantEst, gotEst, 0.0001)
+}
+
+func TestCpcSnapshotLoadRejectsCorruptedBlob(t *testing.T) {
+	ctx := context.Background()
+	const seed = internal.DEFAULT_UPDATE_SEED
+
+	sketch, err := cpc.NewCpcSketch(11, seed)
+	require.NoError(t, err)
+	require.NoError(t, sketch.UpdateUint64(1))
+
+	store := NewMemoryStore()
+	snapshotter := &CpcSnapshotter{Store: store, Prefix: "cpc/corrupt"}
+	require.NoError(t, snapshotter.Snapshot(ctx, sketch))
+
+	blobKey, _ := snapshotKeys("cpc/corrupt")
+	data, err := store.Get(ctx, blobKey)
+	require.NoError(t, err)
+	corrupted := append([]byte(nil), data...)
+	corrupted[0] ^= 0xff
+	require.NoError(t, store.Put(ctx, blobKey, corrupted))
+
+	loader := &CpcLoader{Store: store, Prefix: "cpc/corrupt"}
+	_, err = loader.Load(ctx, seed)
+	require.Error(t, err)
+}
+
+func TestFrequenciesSnapshotRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	hasher := testStringHasher{}
+
+	sketch, err := frequencies.NewItemsSketchWithMaxMapSize[string](1<<8, hasher)
+	require.NoError(t, err)
+	require.NoError(t, sketch.Update("a"))
+	require.NoError(t, sketch.Update("b"))
+	require.NoError(t, sketch.Update("a"))
+
+	store := NewMemoryStore()
+	snapshotter := &FrequenciesSnapshotter[string]{Store: store, Prefix: "frequencies/demo"}
+	require.NoError(t, snapshotter.Snapshot(ctx, sketch))
+
+	loader := &FrequenciesLoader[string]{Store: store, Prefix: "frequencies/demo"}
+	restored, err := loader.Load(ctx, hasher)
+	require.NoError(t, err)
+	require.Equal(t, sketch.GetStreamLength(), restored.GetStreamLength())
+}
@@ -0,0 +1,58 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sketchstore
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testStoreRoundTrip(t *testing.T, store Store) {
+	ctx := context.Background()
+
+	_, err := store.Get(ctx, "missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	require.NoError(t, store.Put(ctx, "a/b", []byte("hello")))
+	require.NoError(t, store.Put(ctx, "a/c", []byte("world")))
+
+	got, err := store.Get(ctx, "a/b")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("hello"), got)
+
+	keys, err := store.List(ctx, "a/")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"a/b", "a/c"}, keys)
+
+	require.NoError(t, store.Delete(ctx, "a/b"))
+	_, err = store.Get(ctx, "a/b")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestMemoryStoreRoundTrip(t *testing.T) {
+	testStoreRoundTrip(t, NewMemoryStore())
+}
+
+func TestFileStoreRoundTrip(t *testing.T) {
+	store, err := NewFileStore(t.TempDir())
+	require.NoError(t, err)
+	testStoreRoundTrip(t, store)
+}